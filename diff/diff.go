@@ -0,0 +1,234 @@
+// Package diff compares two resolved dependency trees (build lists) and
+// reports what changed between them: packages added, removed, or bumped,
+// classified by semver severity so CI can gate on the bumps that matter.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Bump classifies how a package's version changed.
+type Bump string
+
+const (
+	BumpMajor      Bump = "major"
+	BumpMinor      Bump = "minor"
+	BumpPatch      Bump = "patch"
+	BumpPrerelease Bump = "prerelease"
+)
+
+// Change describes how a single package differs between two build lists.
+type Change struct {
+	Name       string `json:"name"`
+	OldVersion string `json:"oldVersion,omitempty"`
+	NewVersion string `json:"newVersion,omitempty"`
+	Bump       Bump   `json:"bump,omitempty"`
+	Downgrade  bool   `json:"downgrade,omitempty"`
+}
+
+// Report is the full comparison between a previous and a next build list.
+type Report struct {
+	Added   []Change `json:"added"`
+	Removed []Change `json:"removed"`
+	Changed []Change `json:"changed"`
+}
+
+// HasBumpAtOrAbove reports whether the report contains any change whose
+// bump severity is at least as large as min (major > minor > patch >
+// prerelease), useful for gating CI on "no major bumps without review".
+func (r *Report) HasBumpAtOrAbove(min Bump) bool {
+	for _, c := range r.Changed {
+		if bumpSeverity(c.Bump) >= bumpSeverity(min) {
+			return true
+		}
+	}
+	return false
+}
+
+func bumpSeverity(b Bump) int {
+	switch b {
+	case BumpMajor:
+		return 3
+	case BumpMinor:
+		return 2
+	case BumpPatch:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Compare returns the Report describing how next differs from previous,
+// where both are build lists (package name -> resolved version).
+func Compare(previous, next map[string]string) *Report {
+	report := &Report{}
+
+	for name, newVersion := range next {
+		oldVersion, existed := previous[name]
+		if !existed {
+			report.Added = append(report.Added, Change{Name: name, NewVersion: newVersion})
+			continue
+		}
+		if oldVersion == newVersion {
+			continue
+		}
+		report.Changed = append(report.Changed, Change{
+			Name:       name,
+			OldVersion: oldVersion,
+			NewVersion: newVersion,
+			Bump:       classifyBump(oldVersion, newVersion),
+			Downgrade:  isDowngrade(oldVersion, newVersion),
+		})
+	}
+
+	for name, oldVersion := range previous {
+		if _, stillPresent := next[name]; !stillPresent {
+			report.Removed = append(report.Removed, Change{Name: name, OldVersion: oldVersion})
+		}
+	}
+
+	sort.Slice(report.Added, func(i, j int) bool { return report.Added[i].Name < report.Added[j].Name })
+	sort.Slice(report.Removed, func(i, j int) bool { return report.Removed[i].Name < report.Removed[j].Name })
+	sort.Slice(report.Changed, func(i, j int) bool { return report.Changed[i].Name < report.Changed[j].Name })
+
+	return report
+}
+
+// classifyBump compares old and new as semver versions and returns the
+// highest-order component that differs. It falls back to BumpPrerelease if
+// the versions don't otherwise differ (e.g. 1.0.0-alpha.1 -> 1.0.0-alpha.2).
+func classifyBump(old, new string) Bump {
+	oldVer, oldErr := semver.NewVersion(old)
+	newVer, newErr := semver.NewVersion(new)
+	if oldErr != nil || newErr != nil {
+		return BumpPrerelease
+	}
+
+	switch {
+	case oldVer.Major() != newVer.Major():
+		return BumpMajor
+	case oldVer.Minor() != newVer.Minor():
+		return BumpMinor
+	case oldVer.Patch() != newVer.Patch():
+		return BumpPatch
+	default:
+		return BumpPrerelease
+	}
+}
+
+// isDowngrade reports whether new is semantically older (lower semver
+// precedence) than old, mirroring go get's downgrade protection. Publish
+// times are not compared, and a version that doesn't parse as semver is
+// never reported as a downgrade.
+func isDowngrade(old, new string) bool {
+	oldVer, oldErr := semver.NewVersion(old)
+	newVer, newErr := semver.NewVersion(new)
+	if oldErr != nil || newErr != nil {
+		return false
+	}
+	return newVer.LessThan(oldVer)
+}
+
+// lockfile is the subset of an npm-style package-lock.json (v1/v2) needed to
+// recover a flat build list: a nested "dependencies" tree.
+type lockfile struct {
+	LockfileVersion int                    `json:"lockfileVersion"`
+	Dependencies    map[string]lockPackage `json:"dependencies"`
+}
+
+type lockPackage struct {
+	Version      string                 `json:"version"`
+	Dependencies map[string]lockPackage `json:"dependencies"`
+}
+
+// lockfileV3 is the subset of an npm lockfileVersion 3 package-lock.json
+// needed to recover a flat build list: a flat "packages" map keyed by
+// node_modules path, with the root package itself at "".
+type lockfileV3 struct {
+	Packages map[string]lockPackageV3 `json:"packages"`
+}
+
+type lockPackageV3 struct {
+	Version string `json:"version"`
+}
+
+// packageInfo is the shape this tool's own "*-latest.json" files use: a flat
+// map of dependency name to resolved version.
+type packageInfo struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+// LoadBuildList reads a previously saved package-lock.json or one of this
+// tool's "*-latest.json" files and returns it as a flat build list.
+func LoadBuildList(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var probe struct {
+		LockfileVersion int                      `json:"lockfileVersion"`
+		Packages        map[string]lockPackageV3 `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if len(probe.Packages) > 0 {
+		var lf lockfileV3
+		if err := json.Unmarshal(data, &lf); err != nil {
+			return nil, fmt.Errorf("parsing %s as a lockfileVersion 3 package-lock.json: %w", path, err)
+		}
+		buildList := make(map[string]string, len(lf.Packages))
+		for path, pkg := range lf.Packages {
+			if path == "" {
+				continue // the root package itself, not a dependency
+			}
+			buildList[nodeModulesName(path)] = pkg.Version
+		}
+		return buildList, nil
+	}
+
+	if probe.LockfileVersion > 0 {
+		var lf lockfile
+		if err := json.Unmarshal(data, &lf); err != nil {
+			return nil, fmt.Errorf("parsing %s as a package-lock.json: %w", path, err)
+		}
+		buildList := make(map[string]string)
+		flattenLockPackages(lf.Dependencies, buildList)
+		return buildList, nil
+	}
+
+	var info packageInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("parsing %s as a latest-versions file: %w", path, err)
+	}
+	return info.Dependencies, nil
+}
+
+func flattenLockPackages(packages map[string]lockPackage, out map[string]string) {
+	for name, pkg := range packages {
+		out[name] = pkg.Version
+		if pkg.Dependencies != nil {
+			flattenLockPackages(pkg.Dependencies, out)
+		}
+	}
+}
+
+// nodeModulesName recovers a package's name from its node_modules path
+// (e.g. "node_modules/a/node_modules/b" -> "b").
+func nodeModulesName(path string) string {
+	const prefix = "node_modules/"
+	if idx := strings.LastIndex(path, prefix); idx >= 0 {
+		return path[idx+len(prefix):]
+	}
+	return path
+}
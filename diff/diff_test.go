@@ -0,0 +1,135 @@
+package diff
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	previous := map[string]string{
+		"kept":     "1.0.0",
+		"upgraded": "1.0.0",
+		"removed":  "1.0.0",
+	}
+	next := map[string]string{
+		"kept":     "1.0.0",
+		"upgraded": "2.0.0",
+		"added":    "1.0.0",
+	}
+
+	report := Compare(previous, next)
+
+	if len(report.Added) != 1 || report.Added[0].Name != "added" {
+		t.Errorf("Added = %+v, want [added@1.0.0]", report.Added)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Name != "removed" {
+		t.Errorf("Removed = %+v, want [removed@1.0.0]", report.Removed)
+	}
+	if len(report.Changed) != 1 || report.Changed[0].Name != "upgraded" || report.Changed[0].Bump != BumpMajor {
+		t.Errorf("Changed = %+v, want [upgraded 1.0.0->2.0.0 major]", report.Changed)
+	}
+}
+
+func TestCompareClassifiesBumps(t *testing.T) {
+	cases := []struct {
+		old, new string
+		want     Bump
+	}{
+		{"1.0.0", "2.0.0", BumpMajor},
+		{"1.0.0", "1.1.0", BumpMinor},
+		{"1.0.0", "1.0.1", BumpPatch},
+	}
+
+	for _, c := range cases {
+		report := Compare(map[string]string{"pkg": c.old}, map[string]string{"pkg": c.new})
+		if len(report.Changed) != 1 || report.Changed[0].Bump != c.want {
+			t.Errorf("Compare(%s, %s): bump = %+v, want %s", c.old, c.new, report.Changed, c.want)
+		}
+	}
+}
+
+func TestCompareDetectsDowngrade(t *testing.T) {
+	report := Compare(map[string]string{"pkg": "2.0.0"}, map[string]string{"pkg": "1.0.0"})
+	if len(report.Changed) != 1 || !report.Changed[0].Downgrade {
+		t.Errorf("Changed = %+v, want a downgrade warning", report.Changed)
+	}
+}
+
+func TestLoadBuildListFromLockfile(t *testing.T) {
+	path := writeTempFile(t, `{
+		"name": "app",
+		"version": "1.0.0",
+		"lockfileVersion": 3,
+		"requires": true,
+		"dependencies": {
+			"a": {
+				"version": "1.0.0",
+				"dependencies": {
+					"shared": {"version": "1.2.0"}
+				}
+			}
+		}
+	}`)
+
+	buildList, err := LoadBuildList(path)
+	if err != nil {
+		t.Fatalf("LoadBuildList: %v", err)
+	}
+	if buildList["a"] != "1.0.0" || buildList["shared"] != "1.2.0" {
+		t.Errorf("buildList = %+v, want a@1.0.0 and shared@1.2.0", buildList)
+	}
+}
+
+func TestLoadBuildListFromLockfileV3(t *testing.T) {
+	path := writeTempFile(t, `{
+		"name": "app",
+		"version": "1.0.0",
+		"lockfileVersion": 3,
+		"requires": true,
+		"packages": {
+			"": {"name": "app", "version": "1.0.0"},
+			"node_modules/a": {"version": "1.0.0"},
+			"node_modules/shared": {"version": "1.2.0"}
+		}
+	}`)
+
+	buildList, err := LoadBuildList(path)
+	if err != nil {
+		t.Fatalf("LoadBuildList: %v", err)
+	}
+	if buildList["a"] != "1.0.0" || buildList["shared"] != "1.2.0" {
+		t.Errorf("buildList = %+v, want a@1.0.0 and shared@1.2.0", buildList)
+	}
+	if _, present := buildList["app"]; present {
+		t.Errorf("buildList = %+v, the root package itself should not be in its own build list", buildList)
+	}
+}
+
+func TestLoadBuildListFromLatestFile(t *testing.T) {
+	path := writeTempFile(t, `{
+		"name": "app",
+		"version": "1.0.0",
+		"dependencies": {"a": "1.0.0", "b": "2.0.0"}
+	}`)
+
+	buildList, err := LoadBuildList(path)
+	if err != nil {
+		t.Fatalf("LoadBuildList: %v", err)
+	}
+	if buildList["a"] != "1.0.0" || buildList["b"] != "2.0.0" {
+		t.Errorf("buildList = %+v, want a@1.0.0 and b@2.0.0", buildList)
+	}
+}
+
+func writeTempFile(t *testing.T, contents string) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "*.json")
+	if err != nil {
+		t.Fatalf("CreateTemp: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(contents); err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+	return f.Name()
+}
@@ -0,0 +1,32 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// WriteTable renders the report as a human-readable table.
+func (r *Report) WriteTable(w io.Writer) {
+	for _, c := range r.Added {
+		fmt.Fprintf(w, "+ %s %s\n", c.Name, c.NewVersion)
+	}
+	for _, c := range r.Removed {
+		fmt.Fprintf(w, "- %s %s\n", c.Name, c.OldVersion)
+	}
+	for _, c := range r.Changed {
+		line := fmt.Sprintf("~ %s %s -> %s (%s)", c.Name, c.OldVersion, c.NewVersion, c.Bump)
+		if c.Downgrade {
+			line += " [WARNING: would downgrade]"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// WriteJSON renders the report as machine-readable JSON, suitable for CI to
+// parse and gate on (e.g. fail if any change has bump == "major").
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
@@ -0,0 +1,114 @@
+// Package ecosystem reads and writes the lockfile formats of the major npm
+// clients (npm itself, Yarn, and pnpm) and normalizes all of them to a
+// resolver.Graph, so the resolver, diff, and validation packages work the
+// same regardless of which tool produced the lockfile on disk.
+package ecosystem
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/zen37/npm_packages/registry"
+	"github.com/zen37/npm_packages/resolver"
+)
+
+// Format identifies a lockfile flavor.
+type Format string
+
+const (
+	FormatNpm  Format = "npm"
+	FormatYarn Format = "yarn"
+	FormatPnpm Format = "pnpm"
+)
+
+// Package is a single resolved package, independent of which lockfile format
+// it was read from or will be written to.
+type Package struct {
+	Version      string
+	Resolved     string
+	Integrity    string
+	Dependencies map[string]string
+}
+
+// Lockfile is a lockfile normalized to a flat, format-independent shape.
+type Lockfile struct {
+	Root     string
+	Version  string
+	Packages map[string]Package
+}
+
+// ReadLockfile parses r as a lockfile of the given format.
+func ReadLockfile(format Format, r io.Reader) (*Lockfile, error) {
+	switch format {
+	case FormatNpm:
+		return readNpmLockfile(r)
+	case FormatYarn:
+		return readYarnLockfile(r)
+	case FormatPnpm:
+		return readPnpmLockfile(r)
+	default:
+		return nil, fmt.Errorf("ecosystem: unknown lockfile format %q", format)
+	}
+}
+
+// WriteLockfile renders graph as a lockfile of the given format.
+func WriteLockfile(format Format, w io.Writer, graph *resolver.Graph) error {
+	switch format {
+	case FormatNpm:
+		return writeNpmLockfile(w, graph)
+	case FormatYarn:
+		return writeYarnLockfile(w, graph)
+	case FormatPnpm:
+		return writePnpmLockfile(w, graph)
+	default:
+		return fmt.Errorf("ecosystem: unknown lockfile format %q", format)
+	}
+}
+
+// DetectFormat guesses the lockfile Format a file holds from its name,
+// e.g. for picking a reader based on an --input-lock path.
+func DetectFormat(path string) Format {
+	switch base := filepath.Base(path); {
+	case strings.Contains(base, "yarn.lock"):
+		return FormatYarn
+	case strings.Contains(base, "pnpm-lock"):
+		return FormatPnpm
+	default:
+		return FormatNpm
+	}
+}
+
+// ToGraph builds a resolver.Graph from a parsed Lockfile, so it can seed
+// resolution (freezing pinned versions, the way "npm ci" does) or feed
+// straight into diff/validation without re-resolving anything.
+func ToGraph(lf *Lockfile) *resolver.Graph {
+	packages := make(map[string]resolver.PackageSpec, len(lf.Packages))
+	for name, pkg := range lf.Packages {
+		packages[name] = resolver.PackageSpec{
+			Version:      pkg.Version,
+			Dependencies: pkg.Dependencies,
+			Dist:         registry.DistInfo{Tarball: pkg.Resolved, Integrity: pkg.Integrity},
+		}
+	}
+	return resolver.BuildGraph(lf.Root, packages)
+}
+
+// FromGraph converts a resolved Graph into the format-independent Lockfile
+// shape so it can be rendered in any of the supported lockfile flavors.
+func FromGraph(graph *resolver.Graph) *Lockfile {
+	lf := &Lockfile{Root: graph.Root, Packages: make(map[string]Package, len(graph.Nodes))}
+	if root, ok := graph.Nodes[graph.Root]; ok {
+		lf.Version = root.Version
+	}
+	for name, node := range graph.Nodes {
+		lf.Packages[name] = Package{
+			Version:      node.Version,
+			Resolved:     node.Dist.Tarball,
+			Integrity:    node.Dist.Integrity,
+			Dependencies: node.Dependencies,
+		}
+	}
+	return lf
+}
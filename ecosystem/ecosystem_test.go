@@ -0,0 +1,266 @@
+package ecosystem
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/zen37/npm_packages/registry"
+	"github.com/zen37/npm_packages/resolver"
+)
+
+func TestReadNpmLockfileNested(t *testing.T) {
+	lf, err := readNpmLockfile(strings.NewReader(`{
+		"name": "app",
+		"version": "1.0.0",
+		"lockfileVersion": 2,
+		"dependencies": {
+			"a": {
+				"version": "1.0.0",
+				"resolved": "https://registry.npmjs.org/a/-/a-1.0.0.tgz",
+				"integrity": "sha512-aaaa",
+				"requires": {"shared": "^1.0.0"},
+				"dependencies": {
+					"shared": {"version": "1.2.0"}
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("readNpmLockfile: %v", err)
+	}
+
+	if got := lf.Packages["a"].Version; got != "1.0.0" {
+		t.Errorf("a version = %q, want 1.0.0", got)
+	}
+	if got := lf.Packages["a"].Dependencies["shared"]; got != "^1.0.0" {
+		t.Errorf("a dependency on shared = %q, want ^1.0.0", got)
+	}
+	if got := lf.Packages["shared"].Version; got != "1.2.0" {
+		t.Errorf("shared version = %q, want 1.2.0", got)
+	}
+}
+
+func TestReadNpmLockfileV3(t *testing.T) {
+	lf, err := readNpmLockfile(strings.NewReader(`{
+		"name": "app",
+		"version": "1.0.0",
+		"lockfileVersion": 3,
+		"requires": true,
+		"packages": {
+			"": {"name": "app", "version": "1.0.0", "dependencies": {"a": "^1.0.0"}},
+			"node_modules/a": {
+				"version": "1.0.0",
+				"resolved": "https://registry.npmjs.org/a/-/a-1.0.0.tgz",
+				"integrity": "sha512-aaaa",
+				"dependencies": {"shared": "^1.0.0"}
+			},
+			"node_modules/shared": {"version": "1.2.0"}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("readNpmLockfile: %v", err)
+	}
+
+	if _, present := lf.Packages["app"]; present {
+		t.Errorf("Packages = %+v, the root package itself should not be in its own package set", lf.Packages)
+	}
+	if got := lf.Packages["a"].Version; got != "1.0.0" {
+		t.Errorf("a version = %q, want 1.0.0", got)
+	}
+	if got := lf.Packages["a"].Dependencies["shared"]; got != "^1.0.0" {
+		t.Errorf("a dependency on shared = %q, want ^1.0.0", got)
+	}
+	if got := lf.Packages["shared"].Version; got != "1.2.0" {
+		t.Errorf("shared version = %q, want 1.2.0", got)
+	}
+}
+
+func TestReadYarnV1Lockfile(t *testing.T) {
+	lf, err := readYarnV1Lockfile([]byte(`# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+foo@^1.0.0, foo@^1.2.0:
+  version "1.2.3"
+  resolved "https://registry.yarnpkg.com/foo/-/foo-1.2.3.tgz#deadbeef"
+  integrity sha512-abc123==
+  dependencies:
+    bar "^2.0.0"
+`))
+	if err != nil {
+		t.Fatalf("readYarnV1Lockfile: %v", err)
+	}
+
+	pkg, ok := lf.Packages["foo"]
+	if !ok {
+		t.Fatalf("Packages = %+v, want an entry for foo", lf.Packages)
+	}
+	if pkg.Version != "1.2.3" {
+		t.Errorf("foo version = %q, want 1.2.3", pkg.Version)
+	}
+	if pkg.Dependencies["bar"] != "^2.0.0" {
+		t.Errorf("foo dependency on bar = %q, want ^2.0.0", pkg.Dependencies["bar"])
+	}
+}
+
+func TestReadYarnV2Lockfile(t *testing.T) {
+	lf, err := readYarnV2Lockfile([]byte(`__metadata:
+  version: 6
+  cacheKey: 8
+
+"foo@npm:^1.0.0":
+  version: 1.2.3
+  resolution: "foo@npm:1.2.3"
+  dependencies:
+    bar: "^2.0.0"
+  checksum: abc123
+  languageName: node
+  linkType: hard
+`))
+	if err != nil {
+		t.Fatalf("readYarnV2Lockfile: %v", err)
+	}
+
+	pkg, ok := lf.Packages["foo"]
+	if !ok {
+		t.Fatalf("Packages = %+v, want an entry for foo", lf.Packages)
+	}
+	if pkg.Version != "1.2.3" {
+		t.Errorf("foo version = %q, want 1.2.3", pkg.Version)
+	}
+	if pkg.Dependencies["bar"] != "^2.0.0" {
+		t.Errorf("foo dependency on bar = %q, want ^2.0.0", pkg.Dependencies["bar"])
+	}
+}
+
+func TestReadPnpmLockfile(t *testing.T) {
+	lf, err := readPnpmLockfile(strings.NewReader(`
+lockfileVersion: '6.0'
+packages:
+  /foo@1.2.3:
+    resolution: {integrity: sha512-abc123==}
+    dependencies:
+      bar: 2.0.0
+`))
+	if err != nil {
+		t.Fatalf("readPnpmLockfile: %v", err)
+	}
+
+	pkg, ok := lf.Packages["foo"]
+	if !ok {
+		t.Fatalf("Packages = %+v, want an entry for foo", lf.Packages)
+	}
+	if pkg.Version != "1.2.3" {
+		t.Errorf("foo version = %q, want 1.2.3", pkg.Version)
+	}
+	if pkg.Dependencies["bar"] != "2.0.0" {
+		t.Errorf("foo dependency on bar = %q, want 2.0.0", pkg.Dependencies["bar"])
+	}
+}
+
+func TestWriteNpmLockfile(t *testing.T) {
+	graph := &resolver.Graph{
+		Root: "app",
+		Nodes: map[string]*resolver.Node{
+			"app": {Name: "app", Version: "1.0.0", Dependencies: map[string]string{"a": "^1.0.0"}},
+			"a":   {Name: "a", Version: "1.0.0", Dist: registry.DistInfo{Tarball: "https://registry.npmjs.org/a/-/a-1.0.0.tgz", Integrity: "sha512-aaaa"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeNpmLockfile(&buf, graph); err != nil {
+		t.Fatalf("writeNpmLockfile: %v", err)
+	}
+
+	lf, err := readNpmLockfile(&buf)
+	if err != nil {
+		t.Fatalf("readNpmLockfile(writeNpmLockfile output): %v", err)
+	}
+	if got := lf.Packages["a"].Version; got != "1.0.0" {
+		t.Errorf("a version = %q, want 1.0.0", got)
+	}
+	if got := lf.Packages["a"].Integrity; got != "sha512-aaaa" {
+		t.Errorf("a integrity = %q, want sha512-aaaa", got)
+	}
+}
+
+func TestWriteYarnLockfileKeysByRequestedRanges(t *testing.T) {
+	graph := &resolver.Graph{
+		Root: "app",
+		Nodes: map[string]*resolver.Node{
+			"foo": {
+				Name:    "foo",
+				Version: "1.2.3",
+				Requirements: []resolver.Requirement{
+					{Parent: "app", Package: "foo", Constraint: "^1.0.0"},
+					{Parent: "bar", Package: "foo", Constraint: "^1.2.0"},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeYarnLockfile(&buf, graph); err != nil {
+		t.Fatalf("writeYarnLockfile: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "foo@^1.0.0, foo@^1.2.0:") {
+		t.Errorf("yarn.lock = %q, want a descriptor line listing both requested ranges", out)
+	}
+	if strings.Contains(out, "foo@1.2.3:") {
+		t.Errorf("yarn.lock = %q, must not key the block by the resolved version", out)
+	}
+
+	lf, err := readYarnLockfile(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("readYarnLockfile(writeYarnLockfile output): %v", err)
+	}
+	if got := lf.Packages["foo"].Version; got != "1.2.3" {
+		t.Errorf("foo version = %q, want 1.2.3", got)
+	}
+}
+
+func TestWritePnpmLockfile(t *testing.T) {
+	graph := &resolver.Graph{
+		Root: "app",
+		Nodes: map[string]*resolver.Node{
+			"foo": {Name: "foo", Version: "1.2.3", Dist: registry.DistInfo{Integrity: "sha512-abc123=="}, Dependencies: map[string]string{"bar": "2.0.0"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writePnpmLockfile(&buf, graph); err != nil {
+		t.Fatalf("writePnpmLockfile: %v", err)
+	}
+
+	lf, err := readPnpmLockfile(&buf)
+	if err != nil {
+		t.Fatalf("readPnpmLockfile(writePnpmLockfile output): %v", err)
+	}
+	pkg, ok := lf.Packages["foo"]
+	if !ok {
+		t.Fatalf("Packages = %+v, want an entry for foo", lf.Packages)
+	}
+	if pkg.Version != "1.2.3" {
+		t.Errorf("foo version = %q, want 1.2.3", pkg.Version)
+	}
+	if pkg.Dependencies["bar"] != "2.0.0" {
+		t.Errorf("foo dependency on bar = %q, want 2.0.0", pkg.Dependencies["bar"])
+	}
+}
+
+func TestToGraph(t *testing.T) {
+	lf := &Lockfile{
+		Root: "app",
+		Packages: map[string]Package{
+			"app": {Version: "1.0.0", Dependencies: map[string]string{"a": "^1.0.0"}},
+			"a":   {Version: "1.0.0"},
+		},
+	}
+
+	graph := ToGraph(lf)
+	if got := graph.BuildList()["a"]; got != "1.0.0" {
+		t.Errorf("a = %q, want 1.0.0", got)
+	}
+}
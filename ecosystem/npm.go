@@ -0,0 +1,104 @@
+package ecosystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zen37/npm_packages/resolver"
+)
+
+// npmLockfile is the subset of an npm package-lock.json (v1/v2) needed to
+// recover a flat, format-independent package set: a nested "dependencies"
+// tree.
+type npmLockfile struct {
+	Name            string                    `json:"name"`
+	Version         string                    `json:"version"`
+	LockfileVersion int                       `json:"lockfileVersion"`
+	Dependencies    map[string]npmLockPackage `json:"dependencies"`
+}
+
+type npmLockPackage struct {
+	Version      string                    `json:"version"`
+	Resolved     string                    `json:"resolved"`
+	Integrity    string                    `json:"integrity"`
+	Requires     map[string]string         `json:"requires"`
+	Dependencies map[string]npmLockPackage `json:"dependencies"`
+}
+
+// readNpmLockfile parses a package-lock.json in either its lockfileVersion 3
+// shape (a flat "packages" map keyed by node_modules path, which
+// writeNpmLockfile produces) or the legacy lockfileVersion 1/2 shape (a
+// nested "dependencies" tree).
+func readNpmLockfile(r io.Reader) (*Lockfile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading package-lock.json: %w", err)
+	}
+
+	var probe struct {
+		Name     string                          `json:"name"`
+		Version  string                          `json:"version"`
+		Packages map[string]resolver.LockPackage `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("parsing package-lock.json: %w", err)
+	}
+
+	if len(probe.Packages) > 0 {
+		lf := &Lockfile{Root: probe.Name, Version: probe.Version, Packages: make(map[string]Package, len(probe.Packages))}
+		for path, pkg := range probe.Packages {
+			if path == "" {
+				continue // the root package itself, not a dependency
+			}
+			lf.Packages[nodeModulesName(path)] = Package{
+				Version:      pkg.Version,
+				Resolved:     pkg.Resolved,
+				Integrity:    pkg.Integrity,
+				Dependencies: pkg.Dependencies,
+			}
+		}
+		return lf, nil
+	}
+
+	var raw npmLockfile
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing package-lock.json: %w", err)
+	}
+	lf := &Lockfile{Root: raw.Name, Version: raw.Version, Packages: make(map[string]Package)}
+	flattenNpmPackages(raw.Dependencies, lf.Packages)
+	return lf, nil
+}
+
+func flattenNpmPackages(packages map[string]npmLockPackage, out map[string]Package) {
+	for name, pkg := range packages {
+		out[name] = Package{
+			Version:      pkg.Version,
+			Resolved:     pkg.Resolved,
+			Integrity:    pkg.Integrity,
+			Dependencies: pkg.Requires,
+		}
+		if pkg.Dependencies != nil {
+			flattenNpmPackages(pkg.Dependencies, out)
+		}
+	}
+}
+
+// nodeModulesName recovers a package's name from its node_modules path
+// (e.g. "node_modules/a/node_modules/b" -> "b").
+func nodeModulesName(path string) string {
+	const prefix = "node_modules/"
+	if idx := strings.LastIndex(path, prefix); idx >= 0 {
+		return path[idx+len(prefix):]
+	}
+	return path
+}
+
+// writeNpmLockfile renders graph as a package-lock.json (lockfileVersion 3).
+func writeNpmLockfile(w io.Writer, graph *resolver.Graph) error {
+	lockfile := resolver.BuildLockfile(graph)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(lockfile)
+}
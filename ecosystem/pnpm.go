@@ -0,0 +1,77 @@
+package ecosystem
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/zen37/npm_packages/resolver"
+)
+
+// pnpmLockfile is the subset of a pnpm-lock.yaml needed to recover a flat
+// package set. Packages are keyed by "/name@version" (or
+// "/@scope/name@version" for scoped packages).
+type pnpmLockfile struct {
+	LockfileVersion string                      `yaml:"lockfileVersion"`
+	Packages        map[string]pnpmPackageEntry `yaml:"packages"`
+}
+
+type pnpmPackageEntry struct {
+	Resolution   pnpmResolution    `yaml:"resolution"`
+	Dependencies map[string]string `yaml:"dependencies"`
+}
+
+type pnpmResolution struct {
+	Integrity string `yaml:"integrity"`
+}
+
+func readPnpmLockfile(r io.Reader) (*Lockfile, error) {
+	var raw pnpmLockfile
+	if err := yaml.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("parsing pnpm-lock.yaml: %w", err)
+	}
+
+	lf := &Lockfile{Packages: make(map[string]Package, len(raw.Packages))}
+	for key, entry := range raw.Packages {
+		name, version := splitPnpmKey(key)
+		lf.Packages[name] = Package{
+			Version:      version,
+			Integrity:    entry.Resolution.Integrity,
+			Dependencies: entry.Dependencies,
+		}
+	}
+	return lf, nil
+}
+
+// writePnpmLockfile renders graph as a pnpm-lock.yaml.
+func writePnpmLockfile(w io.Writer, graph *resolver.Graph) error {
+	raw := pnpmLockfile{LockfileVersion: "6.0", Packages: make(map[string]pnpmPackageEntry, len(graph.Nodes))}
+	for name, node := range graph.Nodes {
+		raw.Packages[fmt.Sprintf("/%s@%s", name, node.Version)] = pnpmPackageEntry{
+			Resolution:   pnpmResolution{Integrity: node.Dist.Integrity},
+			Dependencies: node.Dependencies,
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	defer encoder.Close()
+	return encoder.Encode(raw)
+}
+
+// splitPnpmKey splits a pnpm package key ("/name@version" or
+// "/@scope/name@version") into its package name and version.
+func splitPnpmKey(key string) (name, version string) {
+	key = strings.TrimPrefix(key, "/")
+	idx := strings.LastIndex(key, "@")
+	if strings.HasPrefix(key, "@") {
+		if second := strings.Index(key[1:], "@"); second >= 0 {
+			idx = second + 1
+		}
+	}
+	if idx <= 0 {
+		return key, ""
+	}
+	return key[:idx], key[idx+1:]
+}
@@ -0,0 +1,226 @@
+package ecosystem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/zen37/npm_packages/resolver"
+)
+
+// readYarnLockfile parses a yarn.lock, detecting whether it's the classic
+// v1 custom text format or the YAML format Yarn Berry (v2+) uses.
+func readYarnLockfile(r io.Reader) (*Lockfile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading yarn.lock: %w", err)
+	}
+
+	if strings.Contains(string(data), "__metadata:") {
+		return readYarnV2Lockfile(data)
+	}
+	return readYarnV1Lockfile(data)
+}
+
+// readYarnV1Lockfile parses the classic yarn.lock format: blocks of
+// comma-separated "name@range" descriptors followed by indented fields.
+//
+//	foo@^1.0.0, foo@^1.2.0:
+//	  version "1.2.3"
+//	  resolved "https://registry.yarnpkg.com/foo/-/foo-1.2.3.tgz#..."
+//	  integrity sha512-...
+//	  dependencies:
+//	    bar "^2.0.0"
+func readYarnV1Lockfile(data []byte) (*Lockfile, error) {
+	lf := &Lockfile{Packages: make(map[string]Package)}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	var name string
+	var pkg Package
+	inDependencies := false
+
+	flush := func() {
+		if name != "" {
+			lf.Packages[name] = pkg
+		}
+		name, pkg, inDependencies = "", Package{}, false
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case !strings.HasPrefix(line, " "):
+			// A new descriptor block; flush whatever we were building.
+			flush()
+			descriptor := strings.Split(trimmed, ",")[0]
+			descriptor = strings.TrimSuffix(strings.TrimSpace(descriptor), ":")
+			name, _ = splitYarnDescriptor(descriptor)
+		case strings.HasPrefix(line, "    ") && inDependencies:
+			depName, depRange := splitYarnDependencyLine(trimmed)
+			if depName != "" {
+				if pkg.Dependencies == nil {
+					pkg.Dependencies = make(map[string]string)
+				}
+				pkg.Dependencies[depName] = depRange
+			}
+		case strings.HasPrefix(trimmed, "version "):
+			inDependencies = false
+			pkg.Version = unquote(strings.TrimPrefix(trimmed, "version "))
+		case strings.HasPrefix(trimmed, "resolved "):
+			inDependencies = false
+			pkg.Resolved = unquote(strings.TrimPrefix(trimmed, "resolved "))
+		case strings.HasPrefix(trimmed, "integrity "):
+			inDependencies = false
+			pkg.Integrity = unquote(strings.TrimPrefix(trimmed, "integrity "))
+		case trimmed == "dependencies:":
+			inDependencies = true
+		default:
+			inDependencies = false
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading yarn.lock: %w", err)
+	}
+	return lf, nil
+}
+
+// yarnV2Entry is a single package block in a Yarn Berry (v2+) yarn.lock,
+// which is real YAML keyed by descriptor strings like "foo@npm:^1.0.0".
+type yarnV2Entry struct {
+	Version      string            `yaml:"version"`
+	Resolution   string            `yaml:"resolution"`
+	Checksum     string            `yaml:"checksum"`
+	Dependencies map[string]string `yaml:"dependencies"`
+}
+
+func readYarnV2Lockfile(data []byte) (*Lockfile, error) {
+	var raw map[string]yarnV2Entry
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing yarn.lock (v2+): %w", err)
+	}
+
+	lf := &Lockfile{Packages: make(map[string]Package, len(raw))}
+	for descriptor, entry := range raw {
+		if descriptor == "__metadata" || entry.Version == "" {
+			// The "__metadata" block describes the lockfile itself (its
+			// "version" is the lockfile format version), not a package; skip
+			// it along with any entry that carries no version.
+			continue
+		}
+		name, _ := splitYarnDescriptor(descriptor)
+		lf.Packages[name] = Package{
+			Version:      entry.Version,
+			Integrity:    entry.Checksum,
+			Dependencies: entry.Dependencies,
+		}
+	}
+	return lf, nil
+}
+
+// writeYarnLockfile renders graph as a classic (v1) yarn.lock. Yarn Berry
+// reads this format fine on import; this tool doesn't produce the v2+ YAML
+// form since nothing downstream asks for it yet.
+func writeYarnLockfile(w io.Writer, graph *resolver.Graph) error {
+	names := make([]string, 0, len(graph.Nodes))
+	for name := range graph.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.")
+	fmt.Fprintln(w, "# yarn lockfile v1")
+	fmt.Fprintln(w)
+
+	for _, name := range names {
+		node := graph.Nodes[name]
+		fmt.Fprintf(w, "%s:\n", yarnDescriptors(name, node))
+		fmt.Fprintf(w, "  version %q\n", node.Version)
+		if node.Dist.Tarball != "" {
+			fmt.Fprintf(w, "  resolved %q\n", node.Dist.Tarball)
+		}
+		if node.Dist.Integrity != "" {
+			fmt.Fprintf(w, "  integrity %s\n", node.Dist.Integrity)
+		}
+		if len(node.Dependencies) > 0 {
+			fmt.Fprintln(w, "  dependencies:")
+			depNames := make([]string, 0, len(node.Dependencies))
+			for dep := range node.Dependencies {
+				depNames = append(depNames, dep)
+			}
+			sort.Strings(depNames)
+			for _, dep := range depNames {
+				fmt.Fprintf(w, "    %s %q\n", dep, node.Dependencies[dep])
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// yarnDescriptors builds the comma-separated descriptor list yarn.lock keys
+// each block by, e.g. "foo@^1.0.0, foo@^1.2.0" when two dependents request
+// different but compatible ranges of foo. Yarn looks entries up by the
+// range a project's package.json actually requests, not by the resolved
+// version, so the descriptor must list every requested range rather than
+// just the version MVS picked.
+func yarnDescriptors(name string, node *resolver.Node) string {
+	ranges := make(map[string]bool)
+	for _, req := range node.Requirements {
+		ranges[req.Constraint] = true
+	}
+	if len(ranges) == 0 {
+		return fmt.Sprintf("%s@%s", name, node.Version)
+	}
+
+	list := make([]string, 0, len(ranges))
+	for r := range ranges {
+		list = append(list, r)
+	}
+	sort.Strings(list)
+
+	descriptors := make([]string, len(list))
+	for i, r := range list {
+		descriptors[i] = fmt.Sprintf("%s@%s", name, r)
+	}
+	return strings.Join(descriptors, ", ")
+}
+
+// splitYarnDescriptor splits a yarn descriptor ("foo@^1.0.0" or
+// "@scope/foo@npm:^1.0.0") into its package name and range, accounting for
+// the leading "@" a scoped package name carries.
+func splitYarnDescriptor(descriptor string) (name, constraint string) {
+	idx := strings.LastIndex(descriptor, "@")
+	if strings.HasPrefix(descriptor, "@") {
+		if second := strings.Index(descriptor[1:], "@"); second >= 0 {
+			idx = second + 1
+		}
+	}
+	if idx <= 0 {
+		return descriptor, ""
+	}
+	return descriptor[:idx], descriptor[idx+1:]
+}
+
+// splitYarnDependencyLine splits an indented "name \"range\"" line from a
+// dependencies: block into its name and range.
+func splitYarnDependencyLine(line string) (name, constraint string) {
+	fields := strings.SplitN(line, " ", 2)
+	if len(fields) != 2 {
+		return "", ""
+	}
+	return fields[0], unquote(fields[1])
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, `"`)
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
-	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/zen37/npm_packages/registry"
 )
 
 type PackageInfo struct {
@@ -16,6 +18,8 @@ type PackageInfo struct {
 	Dependencies map[string]string `json:"dependencies"`
 }
 
+var client = registry.NewClient()
+
 func main() {
 	packageName, packageVersion, err := parseArguments()
 	if err != nil {
@@ -26,20 +30,12 @@ func main() {
 	fmt.Printf("Package Version: %s\n", packageVersion)
 
 	// Get the dependencies of the specified package version
-	cmd := exec.Command("npm", "view", fmt.Sprintf("%s@%s", packageName, packageVersion), "dependencies", "--json")
-	output, err := cmd.Output()
+	dependencies, err := client.Dependencies(context.Background(), packageName, packageVersion)
 	if err != nil {
 		fmt.Println("Error:", err)
 		return
 	}
 
-	// Parse the JSON output
-	var dependencies map[string]string
-	if err := json.Unmarshal(output, &dependencies); err != nil {
-		fmt.Println("Error parsing JSON:", err)
-		return
-	}
-
 	// Extract the dependency names and sort them
 	var depNames []string
 	for dep := range dependencies {
@@ -140,18 +136,11 @@ func saveLatestVersionsToFile(filePath string, packageInfo PackageInfo) error {
 }
 
 func getLatestVersionForRange(packageName, versionRange string) (string, error) {
-	cmd := exec.Command("npm", "view", packageName, "versions", "--json")
-	output, err := cmd.Output()
+	versions, err := client.Versions(context.Background(), packageName)
 	if err != nil {
 		return "", err
 	}
 
-	// Parse the JSON output
-	var versions []string
-	if err := json.Unmarshal(output, &versions); err != nil {
-		return "", err
-	}
-
 	// Find the highest version that matches the range
 	latestVersion := ""
 	for _, version := range versions {
@@ -171,15 +160,30 @@ func getLatestVersionForRange(packageName, versionRange string) (string, error)
 
 // isVersionInRange checks if a version matches a given range.
 func isVersionInRange(version, versionRange string) bool {
-	// This function should use a library or implement logic to properly parse and match version ranges.
-	// For simplicity, this example does a very basic check.
-	return strings.Contains(versionRange, version)
+	r, err := semver.NewConstraint(versionRange)
+	if err != nil {
+		return false
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+
+	return r.Check(v)
 }
 
 // compareVersions compares two version strings and returns an integer indicating their order.
 func compareVersions(v1, v2 string) int {
-	// Implement version comparison logic here.
-	return strings.Compare(v1, v2)
+	v1Ver, err := semver.NewVersion(v1)
+	if err != nil {
+		return 0
+	}
+	v2Ver, err := semver.NewVersion(v2)
+	if err != nil {
+		return 0
+	}
+	return v1Ver.Compare(v2Ver)
 }
 
 func parseArguments() (string, string, error) {
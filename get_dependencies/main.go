@@ -1,15 +1,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"sort"
-	"strings"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/zen37/npm_packages/registry"
 )
 
 type Config struct {
@@ -24,6 +24,8 @@ type PackageInfo struct {
 
 var config Config
 
+var client = registry.NewClient()
+
 func main() {
 	// Load configuration
 	if err := loadConfig("../config.json"); err != nil {
@@ -40,28 +42,15 @@ func main() {
 	fmt.Printf("Package Version: %s\n", packageVersion)
 
 	// Get the dependencies of the specified package version
-	cmd := exec.Command("npm", "view", fmt.Sprintf("%s@%s", packageName, packageVersion), "dependencies", "--json")
-	output, err := cmd.CombinedOutput() // Use CombinedOutput to capture both stdout and stderr
+	dependencies, err := client.Dependencies(context.Background(), packageName, packageVersion)
 	if err != nil {
-		fmt.Printf("Error: %s\n", string(output)) // Print the combined output
-		return
-	}
-
-	// Handle empty or malformed output
-	if len(output) == 0 || string(output) == "null" {
-		fmt.Println("No dependencies found for the specified package version.")
-		output = []byte("{}") // Ensure output is valid JSON
-	}
-
-	// Parse the JSON output
-	var dependencies map[string]string
-	if err := json.Unmarshal(output, &dependencies); err != nil {
-		fmt.Println("Error parsing JSON:", err)
+		fmt.Println("Error:", err)
 		return
 	}
 
 	// If dependencies are not provided, create an empty map
 	if len(dependencies) == 0 {
+		fmt.Println("No dependencies found for the specified package version.")
 		dependencies = make(map[string]string)
 	}
 
@@ -166,18 +155,11 @@ func saveLatestVersionsToFile(filePath string, packageInfo PackageInfo) error {
 
 func getLatestVersionForRange(packageName, versionRange string) (string, error) {
 	// Fetch all versions of the package
-	cmd := exec.Command("npm", "view", packageName, "versions", "--json")
-	output, err := cmd.Output()
+	versions, err := client.Versions(context.Background(), packageName)
 	if err != nil {
 		return "", err
 	}
 
-	// Parse the JSON output
-	var versions []string
-	if err := json.Unmarshal(output, &versions); err != nil {
-		return "", err
-	}
-
 	// Find the highest version that matches the range
 	latestVersion := ""
 	for _, version := range versions {
@@ -214,8 +196,15 @@ func isVersionInRange(version, versionRange string) bool {
 
 // compareVersions compares two version strings and returns an integer indicating their order.
 func compareVersions(v1, v2 string) int {
-	// Implement version comparison logic here.
-	return strings.Compare(v1, v2)
+	v1Ver, err := semver.NewVersion(v1)
+	if err != nil {
+		return 0
+	}
+	v2Ver, err := semver.NewVersion(v2)
+	if err != nil {
+		return 0
+	}
+	return v1Ver.Compare(v2Ver)
 }
 
 func parseArguments() (string, string, error) {
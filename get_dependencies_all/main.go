@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/zen37/npm_packages/diff"
+	"github.com/zen37/npm_packages/ecosystem"
+	"github.com/zen37/npm_packages/registry"
+	"github.com/zen37/npm_packages/resolver"
 )
 
 type Config struct {
@@ -22,7 +28,23 @@ type PackageInfo struct {
 
 var config Config
 
+var client = registry.NewClient()
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		if err := runDiff(os.Args[2:]); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	concurrency := flag.Int("concurrency", 0, "number of packages to resolve in parallel (default runtime.NumCPU()*4)")
+	nodeVersion := flag.String("node-version", "", "Node.js version to validate declared engines.node ranges against")
+	npmVersion := flag.String("npm-version", "", "npm version to validate declared engines.npm ranges against")
+	inputLock := flag.String("input-lock", "", "seed resolution from an existing package-lock.json, yarn.lock, or pnpm-lock.yaml, freezing its pinned versions like \"npm ci\"")
+	outputFormat := flag.String("output-format", "npm", "lockfile format to emit: npm, yarn, or pnpm")
+	flag.Parse()
 
 	// Load configuration
 	if err := loadConfig("../config.json"); err != nil {
@@ -62,6 +84,152 @@ func main() {
 	}
 
 	fmt.Printf("Latest versions for all dependencies have been saved to %s\n", latestFilePath)
+
+	// Resolve the full dependency graph with MVS and save it as a lockfile
+	// in the --output-format flavor (an npm package-lock.json by default),
+	// so users get a reproducible install manifest rather than just the
+	// flat map above.
+	format := ecosystem.Format(*outputFormat)
+	lockFilePath := getLockFilePath(packageName, packageVersion, format)
+	graph, err := saveLockFile(lockFilePath, packageName, packageVersion, *concurrency, *inputLock, format)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	fmt.Printf("Resolved lockfile for %s@%s has been saved to %s\n", packageName, packageVersion, lockFilePath)
+
+	issues := resolver.Validate(graph, resolver.ValidateOptions{NodeVersion: *nodeVersion, NpmVersion: *npmVersion})
+	resolver.WriteText(os.Stdout, issues)
+	if resolver.HasSeverity(issues, resolver.SeverityError) {
+		os.Exit(1)
+	}
+}
+
+// saveLockFile resolves the full dependency graph of packageName@packageVersion
+// and writes it out as a lockfile in the given format. If inputLockPath is
+// set, the versions it pins are fed into resolution as frozen constraints,
+// mirroring "npm ci": packages the lock already pinned are cross-checked
+// against packageName@packageVersion's own manifest rather than re-resolved
+// from scratch.
+func saveLockFile(filePath, packageName, packageVersion string, concurrency int, inputLockPath string, format ecosystem.Format) (*resolver.Graph, error) {
+	opts := resolver.Options{Client: client, Concurrency: concurrency}
+	if inputLockPath != "" {
+		frozen, err := loadFrozenVersions(inputLockPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading input lockfile: %w", err)
+		}
+		opts.FrozenVersions = frozen
+	}
+
+	ctx := context.Background()
+	root := resolver.Root{Name: packageName, Version: packageVersion}
+	graph, err := resolver.Resolve(ctx, root, opts)
+	if err != nil {
+		return nil, fmt.Errorf("resolving dependency graph: %w", err)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error creating file: %w", err)
+	}
+	defer file.Close()
+
+	if err := ecosystem.WriteLockfile(format, file, graph); err != nil {
+		return nil, fmt.Errorf("error writing lockfile: %w", err)
+	}
+
+	return graph, nil
+}
+
+// loadFrozenVersions reads an existing lockfile at path, detecting its
+// format from the filename, and returns the exact version it pinned for
+// each package, so saveLockFile can feed them into resolution as frozen
+// constraints.
+func loadFrozenVersions(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer file.Close()
+
+	lf, err := ecosystem.ReadLockfile(ecosystem.DetectFormat(path), file)
+	if err != nil {
+		return nil, err
+	}
+
+	frozen := make(map[string]string, len(lf.Packages))
+	for name, pkg := range lf.Packages {
+		frozen[name] = pkg.Version
+	}
+	return frozen, nil
+}
+
+// runDiff resolves packageName@packageVersion and reports how its build
+// list differs from the build list recorded in previousLockPath (a
+// package-lock.json or one of this tool's "*-latest.json" files), so users
+// can review an upgrade before accepting it.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	jsonOut := fs.String("json-out", "", "path to also write the report as JSON, for CI gating")
+	failOn := fs.String("fail-on", "", "exit non-zero if any change is at or above this bump severity (major, minor, patch)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rest := fs.Args()
+	if len(rest) < 3 {
+		return fmt.Errorf("Usage: go run main.go diff [--json-out path] [--fail-on major] <previous-lock.json> <packageName> <version>")
+	}
+	previousLockPath, packageName, packageVersion := rest[0], rest[1], rest[2]
+
+	previous, err := diff.LoadBuildList(previousLockPath)
+	if err != nil {
+		return fmt.Errorf("loading previous build list: %w", err)
+	}
+
+	graph, err := resolver.Resolve(context.Background(), resolver.Root{Name: packageName, Version: packageVersion}, resolver.Options{Client: client})
+	if err != nil {
+		return fmt.Errorf("resolving dependency graph: %w", err)
+	}
+
+	report := diff.Compare(previous, graph.BuildList())
+	report.WriteTable(os.Stdout)
+
+	if *jsonOut != "" {
+		file, err := os.Create(*jsonOut)
+		if err != nil {
+			return fmt.Errorf("error creating file: %w", err)
+		}
+		defer file.Close()
+		if err := report.WriteJSON(file); err != nil {
+			return fmt.Errorf("error writing JSON to file: %w", err)
+		}
+	}
+
+	if *failOn != "" && report.HasBumpAtOrAbove(diff.Bump(*failOn)) {
+		return fmt.Errorf("found a change at or above severity %q", *failOn)
+	}
+
+	return nil
+}
+
+func getLockFilePath(packageName, packageVersion string, format ecosystem.Format) string {
+	fileName := fmt.Sprintf("%s@%s-%s", packageName, packageVersion, lockFileName(format))
+	return filepath.Join(config.TestdataPath, fileName)
+}
+
+// lockFileName returns the conventional lockfile name for format, mirroring
+// what the corresponding package manager would write to a project root.
+func lockFileName(format ecosystem.Format) string {
+	switch format {
+	case ecosystem.FormatYarn:
+		return "yarn.lock"
+	case ecosystem.FormatPnpm:
+		return "pnpm-lock.yaml"
+	default:
+		return "package-lock.json"
+	}
 }
 
 // getAllDependencies recursively fetches all dependencies for a given package version.
@@ -81,21 +249,18 @@ func getAllDependencies(packageName, packageVersion string) (map[string]string,
 		seen[current] = true
 
 		// Get the dependencies for the current package
-		cmd := exec.Command("npm", "view", current, "dependencies", "--json")
-		//output, err := cmd.Output()
-		output, err := cmd.CombinedOutput() // Use CombinedOutput to capture both stdout and stderr
-		if err != nil {
-			fmt.Printf("Error: %s\n", string(output)) // Print the combined output
-			return nil, err
-		}
-
-		if len(output) == 0 || string(output) == "null" {
-			output = []byte("{}")
+		name, version := splitPackageAtVersion(current)
+		if version == "" {
+			var err error
+			version, err = client.LatestVersion(context.Background(), name)
+			if err != nil {
+				return nil, fmt.Errorf("resolving latest version for %s: %w", name, err)
+			}
 		}
 
-		var deps map[string]string
-		if err := json.Unmarshal(output, &deps); err != nil {
-			return nil, err
+		deps, err := client.Dependencies(context.Background(), name, version)
+		if err != nil {
+			return nil, fmt.Errorf("fetching dependencies for %s: %w", current, err)
 		}
 
 		for dep, versionRange := range deps {
@@ -109,6 +274,18 @@ func getAllDependencies(packageName, packageVersion string) (map[string]string,
 	return dependencies, nil
 }
 
+// splitPackageAtVersion splits a "name@version" task string into its name
+// and version. version is empty when current carries no version (e.g. a
+// dependency discovered without a pinned version), in which case the latest
+// published version should be resolved separately.
+func splitPackageAtVersion(current string) (name, version string) {
+	i := strings.LastIndex(current, "@")
+	if i <= 0 {
+		return current, ""
+	}
+	return current[:i], current[i+1:]
+}
+
 // saveLatestVersionsToFile saves the latest matching versions of dependencies to a JSON file.
 func saveLatestVersionsToFile(filePath string, packageInfo PackageInfo) error {
 	latestVersions := make(map[string]string)
@@ -144,17 +321,11 @@ func saveLatestVersionsToFile(filePath string, packageInfo PackageInfo) error {
 }
 
 func getLatestVersionForRange(packageName, versionRange string) (string, error) {
-	cmd := exec.Command("npm", "view", packageName, "versions", "--json")
-	output, err := cmd.Output()
+	versions, err := client.Versions(context.Background(), packageName)
 	if err != nil {
 		return "", err
 	}
 
-	var versions []string
-	if err := json.Unmarshal(output, &versions); err != nil {
-		return "", err
-	}
-
 	latestVersion := ""
 	for _, version := range versions {
 		if isVersionInRange(version, versionRange) {
@@ -198,12 +369,13 @@ func compareVersions(v1, v2 string) int {
 }
 
 func parseArguments() (string, string, error) {
-	if len(os.Args) < 3 {
-		return "", "", fmt.Errorf("Usage: go run main.go <packageName> <version>")
+	args := flag.Args()
+	if len(args) < 2 {
+		return "", "", fmt.Errorf("Usage: go run main.go [--concurrency N] <packageName> <version>")
 	}
 
-	packageName := os.Args[1]
-	packageVersion := os.Args[2]
+	packageName := args[0]
+	packageVersion := args[1]
 
 	return packageName, packageVersion, nil
 }
@@ -0,0 +1,231 @@
+// Package registry provides a client for talking to an npm-compatible
+// registry (https://registry.npmjs.org by default) over HTTP, replacing
+// shell-outs to the npm CLI.
+package registry
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the registry used when no base URL is configured.
+const DefaultBaseURL = "https://registry.npmjs.org"
+
+// DefaultTimeout bounds a single request when no timeout is configured.
+const DefaultTimeout = 15 * time.Second
+
+// DistInfo describes the publishable artifact for a single package version.
+type DistInfo struct {
+	Tarball   string `json:"tarball"`
+	Shasum    string `json:"shasum"`
+	Integrity string `json:"integrity"`
+}
+
+// PeerDependencyMeta describes metadata about a single peer dependency, such
+// as whether it's optional.
+type PeerDependencyMeta struct {
+	Optional bool `json:"optional"`
+}
+
+// VersionInfo is the subset of a single version's manifest the tool cares
+// about.
+type VersionInfo struct {
+	Name                 string                       `json:"name"`
+	Version              string                       `json:"version"`
+	Dependencies         map[string]string            `json:"dependencies"`
+	PeerDependencies     map[string]string            `json:"peerDependencies"`
+	PeerDependenciesMeta map[string]PeerDependencyMeta `json:"peerDependenciesMeta"`
+	Engines              map[string]string            `json:"engines"`
+	Deprecated           string                       `json:"deprecated"`
+	Dist                 DistInfo                     `json:"dist"`
+}
+
+// Packument is the full document a registry returns for GET /<package>.
+type Packument struct {
+	Name     string                 `json:"name"`
+	DistTags map[string]string      `json:"dist-tags"`
+	Versions map[string]VersionInfo `json:"versions"`
+	Time     map[string]string      `json:"time"`
+}
+
+// cacheEntry holds the last response seen for a URL so it can be revalidated
+// with If-None-Match instead of re-fetched in full.
+type cacheEntry struct {
+	etag string
+	body []byte
+}
+
+// Client fetches package metadata from an npm registry.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL overrides the registry base URL, e.g. for a private registry
+// or a test server.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient overrides the underlying *http.Client, e.g. to set a custom
+// transport or timeout.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout sets the per-request timeout on the Client's http.Client.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// NewClient returns a Client configured with the given options, defaulting
+// to the public npm registry and a 15s request timeout.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		baseURL:    DefaultBaseURL,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		cache:      make(map[string]cacheEntry),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// PackageMetadata fetches the full packument for name.
+func (c *Client) PackageMetadata(ctx context.Context, name string) (*Packument, error) {
+	body, err := c.get(ctx, fmt.Sprintf("%s/%s", c.baseURL, url.PathEscape(name)))
+	if err != nil {
+		return nil, fmt.Errorf("fetching packument for %s: %w", name, err)
+	}
+
+	var p Packument
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("parsing packument for %s: %w", name, err)
+	}
+	return &p, nil
+}
+
+// Versions returns every published version of name, in no particular order.
+func (c *Client) Versions(ctx context.Context, name string) ([]string, error) {
+	p, err := c.PackageMetadata(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, 0, len(p.Versions))
+	for v := range p.Versions {
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// Dependencies returns the declared dependencies of name@version.
+func (c *Client) Dependencies(ctx context.Context, name, version string) (map[string]string, error) {
+	p, err := c.PackageMetadata(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	v, ok := p.Versions[version]
+	if !ok {
+		return nil, fmt.Errorf("version %s not found for package %s", version, name)
+	}
+	return v.Dependencies, nil
+}
+
+// LatestVersion returns the version name's "latest" dist-tag points at.
+func (c *Client) LatestVersion(ctx context.Context, name string) (string, error) {
+	p, err := c.PackageMetadata(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	latest, ok := p.DistTags["latest"]
+	if !ok {
+		return "", fmt.Errorf("package %s has no \"latest\" dist-tag", name)
+	}
+	return latest, nil
+}
+
+// get performs a GET against reqURL, revalidating against the cached entry
+// (if any) with If-None-Match and requesting a gzip-encoded response body.
+func (c *Client) get(ctx context.Context, reqURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	c.mu.Lock()
+	entry, cached := c.cache[reqURL]
+	c.mu.Unlock()
+	if cached && entry.etag != "" {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached {
+		return entry.body, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, reqURL)
+	}
+
+	reader, err := decodeBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		c.mu.Lock()
+		c.cache[reqURL] = cacheEntry{etag: etag, body: body}
+		c.mu.Unlock()
+	}
+
+	return body, nil
+}
+
+// decodeBody returns a reader over resp.Body, transparently gunzipping it
+// when the server honored our Accept-Encoding: gzip.
+func decodeBody(resp *http.Response) (io.Reader, error) {
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		return resp.Body, nil
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing gzip response: %w", err)
+	}
+	return gz, nil
+}
@@ -0,0 +1,124 @@
+package registry
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientPackageMetadata(t *testing.T) {
+	packument := Packument{
+		Name:     "left-pad",
+		DistTags: map[string]string{"latest": "1.3.0"},
+		Versions: map[string]VersionInfo{
+			"1.3.0": {
+				Name:         "left-pad",
+				Version:      "1.3.0",
+				Dependencies: map[string]string{"foo": "^1.0.0"},
+			},
+		},
+	}
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/left-pad" {
+			t.Fatalf("unexpected path %q", r.URL.Path)
+		}
+
+		body, err := json.Marshal(packument)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("ETag", `"abc123"`)
+		w.WriteHeader(http.StatusOK)
+		gz := gzip.NewWriter(w)
+		gz.Write(body)
+		gz.Close()
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+
+	got, err := client.PackageMetadata(context.Background(), "left-pad")
+	if err != nil {
+		t.Fatalf("PackageMetadata: %v", err)
+	}
+	if got.Name != packument.Name {
+		t.Errorf("Name = %q, want %q", got.Name, packument.Name)
+	}
+
+	deps, err := client.Dependencies(context.Background(), "left-pad", "1.3.0")
+	if err != nil {
+		t.Fatalf("Dependencies: %v", err)
+	}
+	if deps["foo"] != "^1.0.0" {
+		t.Errorf("Dependencies[foo] = %q, want ^1.0.0", deps["foo"])
+	}
+
+	if requests != 2 {
+		t.Errorf("made %d requests, want 2", requests)
+	}
+}
+
+func TestClientVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Packument{
+			Name: "left-pad",
+			Versions: map[string]VersionInfo{
+				"1.0.0": {Version: "1.0.0"},
+				"1.1.0": {Version: "1.1.0"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+
+	versions, err := client.Versions(context.Background(), "left-pad")
+	if err != nil {
+		t.Fatalf("Versions: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Errorf("got %d versions, want 2", len(versions))
+	}
+}
+
+func TestClientLatestVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Packument{
+			Name:     "left-pad",
+			DistTags: map[string]string{"latest": "1.3.0"},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+
+	latest, err := client.LatestVersion(context.Background(), "left-pad")
+	if err != nil {
+		t.Fatalf("LatestVersion: %v", err)
+	}
+	if latest != "1.3.0" {
+		t.Errorf("LatestVersion = %q, want 1.3.0", latest)
+	}
+}
+
+func TestClientUnknownVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Packument{Name: "left-pad", Versions: map[string]VersionInfo{}})
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL))
+
+	if _, err := client.Dependencies(context.Background(), "left-pad", "9.9.9"); err == nil {
+		t.Fatal("expected an error for an unknown version, got nil")
+	}
+}
@@ -0,0 +1,27 @@
+package resolver
+
+import "github.com/zen37/npm_packages/registry"
+
+// PackageSpec pins a single package to an already-known version and
+// dependency set, bypassing MVS. It's used to seed a Graph from an existing
+// lockfile instead of resolving one from the registry.
+type PackageSpec struct {
+	Version      string
+	Dependencies map[string]string
+	Dist         registry.DistInfo
+}
+
+// BuildGraph constructs a Graph directly from a resolved package set, e.g.
+// one parsed from an existing package-lock.json, yarn.lock, or
+// pnpm-lock.yaml. Unlike Resolve, it performs no version selection of its
+// own: every package is taken at the version the caller supplies.
+func BuildGraph(root string, packages map[string]PackageSpec) *Graph {
+	g := newGraph(root)
+	for name, spec := range packages {
+		n := g.node(name)
+		n.Version = spec.Version
+		n.Dependencies = spec.Dependencies
+		n.Dist = spec.Dist
+	}
+	return g
+}
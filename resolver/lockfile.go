@@ -0,0 +1,56 @@
+package resolver
+
+// Lockfile is an npm-style package-lock.json (lockfileVersion 3): a flat
+// "packages" map keyed by the node_modules path npm would install each
+// package at (the root package itself is keyed by the empty string),
+// rather than the nested per-parent "dependencies" tree earlier lockfile
+// versions used.
+type Lockfile struct {
+	Name            string                 `json:"name"`
+	Version         string                 `json:"version"`
+	LockfileVersion int                    `json:"lockfileVersion"`
+	Requires        bool                   `json:"requires"`
+	Packages        map[string]LockPackage `json:"packages"`
+}
+
+// LockPackage is a single package entry within a Lockfile's "packages" map.
+// Dependencies holds the requested semver ranges declared by this package's
+// own manifest, not resolved versions; resolving a name to a version means
+// looking it up elsewhere in Packages.
+type LockPackage struct {
+	Name         string            `json:"name,omitempty"`
+	Version      string            `json:"version,omitempty"`
+	Resolved     string            `json:"resolved,omitempty"`
+	Integrity    string            `json:"integrity,omitempty"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+// BuildLockfile renders graph as an npm-style package-lock.json.
+func BuildLockfile(graph *Graph) *Lockfile {
+	lock := &Lockfile{
+		Name:            graph.Root,
+		LockfileVersion: 3,
+		Requires:        true,
+		Packages:        map[string]LockPackage{},
+	}
+
+	root, ok := graph.Nodes[graph.Root]
+	if !ok {
+		return lock
+	}
+	lock.Version = root.Version
+	lock.Packages[""] = LockPackage{Name: graph.Root, Version: root.Version, Dependencies: root.Dependencies}
+
+	for name, node := range graph.Nodes {
+		if name == graph.Root {
+			continue
+		}
+		lock.Packages["node_modules/"+name] = LockPackage{
+			Version:      node.Version,
+			Resolved:     node.Dist.Tarball,
+			Integrity:    node.Dist.Integrity,
+			Dependencies: node.Dependencies,
+		}
+	}
+	return lock
+}
@@ -0,0 +1,70 @@
+package resolver
+
+import "sync"
+
+// queue is an unbounded, concurrency-safe work queue for tasks whose total
+// size isn't known upfront: workers pop a task, may push more tasks while
+// handling it, and call done when finished. The queue closes itself once
+// pending drops to zero, i.e. once every pushed task has been popped and
+// marked done with no new tasks left outstanding.
+type queue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	items   []task
+	pending int
+	active  int
+	closed  bool
+}
+
+func newQueue() *queue {
+	q := &queue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push enqueues t. Must be balanced by a later call to done, including for
+// the initial root task.
+func (q *queue) push(t task) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items = append(q.items, t)
+	q.pending++
+	q.cond.Signal()
+}
+
+// pop blocks until a task is available or the queue has drained, returning
+// ok=false in the latter case.
+func (q *queue) pop() (task, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 {
+		return task{}, false
+	}
+	t := q.items[0]
+	q.items = q.items[1:]
+	q.active++
+	return t, true
+}
+
+// done marks a popped task as finished, closing the queue (and waking any
+// blocked poppers) once nothing is left outstanding.
+func (q *queue) done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.active--
+	q.pending--
+	if q.pending == 0 {
+		q.closed = true
+		q.cond.Broadcast()
+	}
+}
+
+// inFlight returns the number of tasks currently popped but not yet done.
+func (q *queue) inFlight() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.active
+}
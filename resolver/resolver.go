@@ -0,0 +1,443 @@
+// Package resolver implements Minimum Version Selection (MVS) over the npm
+// registry: for every package reachable from a root, it records every
+// constraint placed on that package by its parents and picks the lowest
+// version satisfying all of them, rather than the BFS last-writer-wins
+// merge the original tooling did.
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/zen37/npm_packages/registry"
+)
+
+// Root identifies the package a Resolve call starts from.
+type Root struct {
+	Name    string
+	Version string
+}
+
+// Requirement records that Parent (at ParentVersion) asked for Package to
+// satisfy Constraint. Parent is empty for the root package.
+type Requirement struct {
+	Parent        string
+	ParentVersion string
+	Package       string
+	Constraint    string
+}
+
+// frozenRequirementParent is the sentinel Requirement.Parent used for a
+// constraint injected from Options.FrozenVersions rather than from an actual
+// dependency edge. It can't collide with a real npm package name, which
+// can't contain parentheses or spaces.
+const frozenRequirementParent = "(input lockfile)"
+
+// upsertRequirement folds req into reqs, replacing any existing requirement
+// from the same Parent rather than appending alongside it. A parent can only
+// be resolved to one version at a time, so once it's re-resolved to a new
+// version, its previous constraint on Package is stale and must not keep
+// counting toward minimumSatisfying.
+func upsertRequirement(reqs []Requirement, req Requirement) []Requirement {
+	for i := range reqs {
+		if reqs[i].Parent == req.Parent {
+			reqs[i] = req
+			return reqs
+		}
+	}
+	return append(reqs, req)
+}
+
+// Node is a single package in the resolved Graph: the version MVS selected
+// for it, the requirements that drove that selection, and the dependency
+// constraints declared by the selected version.
+type Node struct {
+	Name    string
+	Version string
+	Dist    registry.DistInfo
+
+	PeerDependencies     map[string]string
+	PeerDependenciesMeta map[string]registry.PeerDependencyMeta
+	Engines              map[string]string
+	Deprecated           string
+
+	mu           sync.Mutex
+	Requirements []Requirement
+	Dependencies map[string]string
+}
+
+// Graph is the resolved dependency graph rooted at Root.
+type Graph struct {
+	Root string
+
+	mu    sync.Mutex
+	Nodes map[string]*Node
+}
+
+func newGraph(root string) *Graph {
+	return &Graph{Root: root, Nodes: make(map[string]*Node)}
+}
+
+// node returns the Node for name, creating it if this is the first time it
+// has been seen.
+func (g *Graph) node(name string) *Node {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n, ok := g.Nodes[name]
+	if !ok {
+		n = &Node{Name: name}
+		g.Nodes[name] = n
+	}
+	return n
+}
+
+// BuildList returns the version MVS selected for each package in the graph.
+func (g *Graph) BuildList() map[string]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	buildList := make(map[string]string, len(g.Nodes))
+	for name, node := range g.Nodes {
+		buildList[name] = node.Version
+	}
+	return buildList
+}
+
+// Progress reports how a Resolve call is advancing. Update is called from
+// worker goroutines and must be safe for concurrent use.
+type Progress interface {
+	Update(resolved, total, inFlight int)
+}
+
+// stderrProgress is the default Progress: it prints a one-line status to
+// stderr every time it is called.
+type stderrProgress struct{}
+
+func (stderrProgress) Update(resolved, total, inFlight int) {
+	fmt.Fprintf(os.Stderr, "resolved %d/%d, in-flight %d\n", resolved, total, inFlight)
+}
+
+// Options configures a Resolve call.
+type Options struct {
+	// Client is the registry client used to fetch package metadata.
+	Client *registry.Client
+	// Concurrency bounds how many packages are resolved in parallel.
+	// Defaults to runtime.NumCPU()*4.
+	Concurrency int
+	// RequestTimeout bounds a single registry request. Defaults to 10s.
+	RequestTimeout time.Duration
+	// MaxRetries bounds retries of a failed request due to a transient
+	// network error, with exponential backoff between attempts. Defaults
+	// to 3.
+	MaxRetries int
+	// Progress receives periodic updates as packages resolve. Defaults to
+	// a handler that prints to stderr.
+	Progress Progress
+	// FrozenVersions pins specific packages (by name) to an exact version,
+	// folded in as an additional constraint alongside whatever the
+	// dependency graph itself requires. Used to seed resolution from an
+	// existing lockfile the way "npm ci" cross-checks the lock against the
+	// manifest: packages the lock already pinned are resolved to exactly
+	// that version rather than re-resolved from scratch.
+	FrozenVersions map[string]string
+}
+
+func (o Options) withDefaults() Options {
+	if o.Concurrency <= 0 {
+		o.Concurrency = runtime.NumCPU() * 4
+	}
+	if o.RequestTimeout <= 0 {
+		o.RequestTimeout = 10 * time.Second
+	}
+	if o.MaxRetries <= 0 {
+		o.MaxRetries = 3
+	}
+	if o.Progress == nil {
+		o.Progress = stderrProgress{}
+	}
+	return o
+}
+
+// task is a single edge to resolve: parent (at parentVersion) requires name
+// to satisfy constraint. parent is empty for the root.
+type task struct {
+	name, constraint, parent, parentVersion string
+}
+
+// Resolve concurrently walks the dependency graph of root and returns the
+// MVS-resolved Graph: every package reachable from root, each pinned to the
+// minimum version that satisfies every constraint placed on it.
+func Resolve(ctx context.Context, root Root, opts Options) (*Graph, error) {
+	opts = opts.withDefaults()
+	if opts.Client == nil {
+		return nil, errors.New("resolver: Options.Client is required")
+	}
+
+	graph := newGraph(root.Name)
+	q := newQueue()
+	seenEdges := &sync.Map{}
+
+	var resolved, total int32
+	q.push(task{name: root.Name, constraint: root.Version})
+	atomic.AddInt32(&total, 1)
+
+	var firstErr error
+	var firstErrOnce sync.Once
+	recordErr := func(err error) {
+		firstErrOnce.Do(func() { firstErr = err })
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				t, ok := q.pop()
+				if !ok {
+					return
+				}
+
+				if ctx.Err() != nil {
+					q.done()
+					recordErr(ctx.Err())
+					continue
+				}
+
+				edgeKey := fmt.Sprintf("%s->%s@%s", t.parent, t.name, t.constraint)
+				if _, loaded := seenEdges.LoadOrStore(edgeKey, true); loaded {
+					q.done()
+					continue
+				}
+
+				children, err := resolveTask(ctx, opts, graph, t)
+				atomic.AddInt32(&resolved, 1)
+				opts.Progress.Update(int(atomic.LoadInt32(&resolved)), int(atomic.LoadInt32(&total)), q.inFlight())
+				if err != nil {
+					recordErr(err)
+					q.done()
+					continue
+				}
+
+				atomic.AddInt32(&total, int32(len(children)))
+				for _, child := range children {
+					q.push(child)
+				}
+				q.done()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	pruneUnreachable(graph)
+	return graph, nil
+}
+
+// pruneUnreachable removes nodes no longer reachable from Root by walking
+// Dependencies edges. A later requirement can raise a node's MVS-selected
+// version after its old version's children were already expanded into the
+// Graph (e.g. a@1.0.0 pulled in c, then a requirement elsewhere raised a to
+// 2.0.0, which has no dependencies); without this pass those stale children
+// would stick around in BuildList/the emitted lockfile even though nothing
+// in the final resolved tree depends on them.
+func pruneUnreachable(graph *Graph) {
+	graph.mu.Lock()
+	defer graph.mu.Unlock()
+
+	reachable := map[string]bool{graph.Root: true}
+	queue := []string{graph.Root}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		node, ok := graph.Nodes[name]
+		if !ok {
+			continue
+		}
+		for dep := range node.Dependencies {
+			if !reachable[dep] {
+				reachable[dep] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
+
+	for name := range graph.Nodes {
+		if !reachable[name] {
+			delete(graph.Nodes, name)
+		}
+	}
+}
+
+// resolveTask fetches the requirer's target package, folds the new
+// requirement into its node, and returns the child tasks to enqueue if the
+// new requirement raised the node's resolved version.
+func resolveTask(ctx context.Context, opts Options, graph *Graph, t task) ([]task, error) {
+	node := graph.node(t.name)
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	node.Requirements = upsertRequirement(node.Requirements, Requirement{
+		Parent:        t.parent,
+		ParentVersion: t.parentVersion,
+		Package:       t.name,
+		Constraint:    t.constraint,
+	})
+	if frozen, ok := opts.FrozenVersions[t.name]; ok {
+		node.Requirements = upsertRequirement(node.Requirements, Requirement{
+			Parent:     frozenRequirementParent,
+			Package:    t.name,
+			Constraint: "=" + frozen,
+		})
+	}
+
+	packument, err := fetchWithRetry(ctx, opts, t.name)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", t.name, err)
+	}
+
+	version, err := minimumSatisfying(packument, node.Requirements)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", t.name, err)
+	}
+
+	if version == node.Version {
+		// Already resolved to at least this version; the requirement we
+		// just added didn't raise the minimum, so there's nothing new to
+		// expand.
+		return nil, nil
+	}
+
+	info := packument.Versions[version]
+	node.Version = version
+	node.Dist = info.Dist
+	node.Dependencies = info.Dependencies
+	node.PeerDependencies = info.PeerDependencies
+	node.PeerDependenciesMeta = info.PeerDependenciesMeta
+	node.Engines = info.Engines
+	node.Deprecated = info.Deprecated
+
+	children := make([]task, 0, len(info.Dependencies))
+	for dep, constraint := range info.Dependencies {
+		children = append(children, task{name: dep, constraint: constraint, parent: t.name, parentVersion: version})
+	}
+	return children, nil
+}
+
+// fetchWithRetry fetches name's packument, retrying transient network
+// errors with exponential backoff.
+func fetchWithRetry(ctx context.Context, opts Options, name string) (*registry.Packument, error) {
+	var lastErr error
+	for attempt := 0; attempt <= opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(math.Pow(2, float64(attempt-1))) * 100 * time.Millisecond
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, opts.RequestTimeout)
+		p, err := opts.Client.PackageMetadata(reqCtx, name)
+		cancel()
+		if err == nil {
+			return p, nil
+		}
+		lastErr = err
+		if !isTransient(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("giving up after %d attempts: %w", opts.MaxRetries+1, lastErr)
+}
+
+// isTransient reports whether err looks like a retryable network error
+// (timeouts, connection resets) rather than a permanent failure like a 404.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// minimumSatisfying returns the lowest version in packument that satisfies
+// every requirement's constraint.
+func minimumSatisfying(packument *registry.Packument, requirements []Requirement) (string, error) {
+	constraints := make([]*semver.Constraints, 0, len(requirements))
+	for _, req := range requirements {
+		c, err := semver.NewConstraint(req.Constraint)
+		if err != nil {
+			return "", fmt.Errorf("invalid constraint %q from %s: %w", req.Constraint, describeParent(req), err)
+		}
+		constraints = append(constraints, c)
+	}
+
+	versions := make([]*semver.Version, 0, len(packument.Versions))
+	for v := range packument.Versions {
+		sv, err := semver.NewVersion(v)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, sv)
+	}
+
+	var best *semver.Version
+	for _, v := range versions {
+		satisfiesAll := true
+		for _, c := range constraints {
+			if !c.Check(v) {
+				satisfiesAll = false
+				break
+			}
+		}
+		if !satisfiesAll {
+			continue
+		}
+		if best == nil || v.LessThan(best) {
+			best = v
+		}
+	}
+
+	if best == nil {
+		return "", fmt.Errorf("no version of %s satisfies all constraints: %s", packument.Name, constraintSummary(requirements))
+	}
+	return best.Original(), nil
+}
+
+func describeParent(req Requirement) string {
+	switch req.Parent {
+	case "":
+		return "root"
+	case frozenRequirementParent:
+		return "the input lockfile"
+	default:
+		return fmt.Sprintf("%s@%s", req.Parent, req.ParentVersion)
+	}
+}
+
+func constraintSummary(requirements []Requirement) string {
+	summary := ""
+	for i, req := range requirements {
+		if i > 0 {
+			summary += ", "
+		}
+		summary += fmt.Sprintf("%s wants %s", describeParent(req), req.Constraint)
+	}
+	return summary
+}
@@ -0,0 +1,393 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/zen37/npm_packages/registry"
+)
+
+// TestResolveDiamond exercises a diamond: app depends on both a and b, each
+// of which depends on a different range of shared, and MVS should pick the
+// lowest version of shared that satisfies both ranges rather than whichever
+// one was seen last.
+func TestResolveDiamond(t *testing.T) {
+	packuments := map[string]registry.Packument{
+		"app": {
+			Name: "app",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"a": "^1.0.0", "b": "^1.0.0"}},
+			},
+		},
+		"a": {
+			Name: "a",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"shared": ">=1.1.0"}},
+			},
+		},
+		"b": {
+			Name: "b",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"shared": ">=1.2.0 <2.0.0"}},
+			},
+		},
+		"shared": {
+			Name: "shared",
+			Versions: map[string]registry.VersionInfo{
+				"1.1.0": {Dist: registry.DistInfo{Tarball: "https://example.com/shared-1.1.0.tgz"}},
+				"1.2.0": {Dist: registry.DistInfo{Tarball: "https://example.com/shared-1.2.0.tgz"}},
+				"1.3.0": {Dist: registry.DistInfo{Tarball: "https://example.com/shared-1.3.0.tgz"}},
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path[1:]
+		p, ok := packuments[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(p)
+	}))
+	defer server.Close()
+
+	client := registry.NewClient(registry.WithBaseURL(server.URL))
+
+	graph, err := Resolve(context.Background(), Root{Name: "app", Version: "1.0.0"}, Options{Client: client})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	buildList := graph.BuildList()
+	if got := buildList["shared"]; got != "1.2.0" {
+		t.Errorf("shared resolved to %q, want 1.2.0 (the lowest version satisfying both a and b's ranges)", got)
+	}
+	if got := buildList["a"]; got != "1.0.0" {
+		t.Errorf("a resolved to %q, want 1.0.0", got)
+	}
+	if got := buildList["b"]; got != "1.0.0" {
+		t.Errorf("b resolved to %q, want 1.0.0", got)
+	}
+}
+
+func TestResolveUnsatisfiable(t *testing.T) {
+	packuments := map[string]registry.Packument{
+		"app": {
+			Name: "app",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"a": "^1.0.0", "b": "^1.0.0"}},
+			},
+		},
+		"a": {
+			Name: "a",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"shared": "^1.0.0"}},
+			},
+		},
+		"b": {
+			Name: "b",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"shared": "^2.0.0"}},
+			},
+		},
+		"shared": {
+			Name: "shared",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {},
+				"2.0.0": {},
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path[1:]
+		p, ok := packuments[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(p)
+	}))
+	defer server.Close()
+
+	client := registry.NewClient(registry.WithBaseURL(server.URL))
+
+	if _, err := Resolve(context.Background(), Root{Name: "app", Version: "1.0.0"}, Options{Client: client}); err == nil {
+		t.Fatal("expected an error for an unsatisfiable constraint set, got nil")
+	}
+}
+
+// TestResolvePrunesStaleDependencies covers a node whose MVS-selected
+// version is raised after its old version's children were already expanded:
+// app depends only on a (>=1.0.0); a@1.0.0 depends on b and c; b@1.0.0
+// depends on a (^2.0.0), which raises a to 2.0.0 (no dependencies) once that
+// edge is processed. b and c must not survive in the final graph, since
+// nothing in the resolved tree depends on them any more.
+func TestResolvePrunesStaleDependencies(t *testing.T) {
+	packuments := map[string]registry.Packument{
+		"app": {
+			Name: "app",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"a": ">=1.0.0"}},
+			},
+		},
+		"a": {
+			Name: "a",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"b": "^1.0.0", "c": "*"}},
+				"2.0.0": {},
+			},
+		},
+		"b": {
+			Name: "b",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"a": "^2.0.0"}},
+			},
+		},
+		"c": {
+			Name:     "c",
+			Versions: map[string]registry.VersionInfo{"1.0.0": {}},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path[1:]
+		p, ok := packuments[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(p)
+	}))
+	defer server.Close()
+
+	client := registry.NewClient(registry.WithBaseURL(server.URL))
+
+	graph, err := Resolve(context.Background(), Root{Name: "app", Version: "1.0.0"}, Options{Client: client, Concurrency: 1})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	buildList := graph.BuildList()
+	if got := buildList["a"]; got != "2.0.0" {
+		t.Errorf("a resolved to %q, want 2.0.0", got)
+	}
+	if _, present := buildList["b"]; present {
+		t.Errorf("buildList = %+v, b should have been pruned: a@2.0.0 no longer depends on it", buildList)
+	}
+	if _, present := buildList["c"]; present {
+		t.Errorf("buildList = %+v, c should have been pruned: a@2.0.0 no longer depends on it", buildList)
+	}
+}
+
+// TestResolveDropsStaleRequirementsOnParentVersionBump covers a node whose
+// requirement from a given parent must be superseded, not just appended
+// alongside, when that parent is itself later re-resolved to a new version:
+// app depends on p (>=1.0.0), q (^1.0.0), and x (>=1.0.0); p@1.0.0 depends on
+// x (^1.0.0); q@1.0.0 depends on p (^2.0.0), which raises p to 2.0.0, whose
+// only dependency is x (^2.0.0). p's now-stale x^1.0.0 constraint must not
+// stick around forever alongside the x^2.0.0 constraint from p@2.0.0, or x
+// ends up over-constrained by a requirement nothing in the final tree makes.
+func TestResolveDropsStaleRequirementsOnParentVersionBump(t *testing.T) {
+	packuments := map[string]registry.Packument{
+		"app": {
+			Name: "app",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"p": ">=1.0.0", "q": "^1.0.0", "x": ">=1.0.0"}},
+			},
+		},
+		"p": {
+			Name: "p",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"x": "^1.0.0"}},
+				"2.0.0": {Dependencies: map[string]string{"x": "^2.0.0"}},
+			},
+		},
+		"q": {
+			Name: "q",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"p": "^2.0.0"}},
+			},
+		},
+		"x": {
+			Name: "x",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {},
+				"2.0.0": {},
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path[1:]
+		p, ok := packuments[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(p)
+	}))
+	defer server.Close()
+
+	client := registry.NewClient(registry.WithBaseURL(server.URL))
+
+	graph, err := Resolve(context.Background(), Root{Name: "app", Version: "1.0.0"}, Options{Client: client, Concurrency: 1})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	buildList := graph.BuildList()
+	if got := buildList["p"]; got != "2.0.0" {
+		t.Errorf("p resolved to %q, want 2.0.0", got)
+	}
+	if got := buildList["x"]; got != "2.0.0" {
+		t.Errorf("x resolved to %q, want 2.0.0", got)
+	}
+}
+
+type countingProgress struct {
+	mu      sync.Mutex
+	updates int
+}
+
+func (p *countingProgress) Update(resolved, total, inFlight int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.updates++
+}
+
+func TestResolveReportsProgress(t *testing.T) {
+	packuments := map[string]registry.Packument{
+		"app": {
+			Name: "app",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"a": "^1.0.0"}},
+			},
+		},
+		"a": {
+			Name:     "a",
+			Versions: map[string]registry.VersionInfo{"1.0.0": {}},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path[1:]
+		p, ok := packuments[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(p)
+	}))
+	defer server.Close()
+
+	client := registry.NewClient(registry.WithBaseURL(server.URL))
+	progress := &countingProgress{}
+
+	_, err := Resolve(context.Background(), Root{Name: "app", Version: "1.0.0"}, Options{Client: client, Concurrency: 2, Progress: progress})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+	if progress.updates == 0 {
+		t.Error("expected at least one progress update")
+	}
+}
+
+// TestResolveHonorsFrozenVersions covers seeding resolution from an existing
+// lockfile: app's own manifest only requires shared (^1.0.0), which MVS would
+// ordinarily resolve to its minimum, 1.0.0, but a frozen version from the
+// input lockfile pins it to 1.5.0, mirroring "npm ci" installing exactly what the lock
+// recorded rather than whatever the manifest's range would otherwise allow.
+func TestResolveHonorsFrozenVersions(t *testing.T) {
+	packuments := map[string]registry.Packument{
+		"app": {
+			Name: "app",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"shared": "^1.0.0"}},
+			},
+		},
+		"shared": {
+			Name: "shared",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {},
+				"1.5.0": {},
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path[1:]
+		p, ok := packuments[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(p)
+	}))
+	defer server.Close()
+
+	client := registry.NewClient(registry.WithBaseURL(server.URL))
+
+	graph, err := Resolve(context.Background(), Root{Name: "app", Version: "1.0.0"}, Options{
+		Client:         client,
+		FrozenVersions: map[string]string{"shared": "1.5.0"},
+	})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	if got := graph.BuildList()["shared"]; got != "1.5.0" {
+		t.Errorf("shared resolved to %q, want the frozen version 1.5.0", got)
+	}
+}
+
+// TestResolveRejectsFrozenVersionOutsideManifestRange covers a frozen
+// version that conflicts with what the manifest itself requires, which
+// should surface as a normal unsatisfiable-constraint error rather than
+// silently picking one source over the other.
+func TestResolveRejectsFrozenVersionOutsideManifestRange(t *testing.T) {
+	packuments := map[string]registry.Packument{
+		"app": {
+			Name: "app",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"shared": "^2.0.0"}},
+			},
+		},
+		"shared": {
+			Name: "shared",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {},
+				"2.0.0": {},
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path[1:]
+		p, ok := packuments[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(p)
+	}))
+	defer server.Close()
+
+	client := registry.NewClient(registry.WithBaseURL(server.URL))
+
+	_, err := Resolve(context.Background(), Root{Name: "app", Version: "1.0.0"}, Options{
+		Client:         client,
+		FrozenVersions: map[string]string{"shared": "1.0.0"},
+	})
+	if err == nil {
+		t.Fatal("expected an error when the frozen version conflicts with the manifest's own constraint, got nil")
+	}
+}
@@ -0,0 +1,211 @@
+package resolver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Severity classifies how serious an Issue is. CI is expected to fail the
+// build on SeverityError and merely surface SeverityWarning.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a single problem found while validating a resolved Graph.
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Package  string   `json:"package"`
+	Message  string   `json:"message"`
+}
+
+// ValidateOptions configures engine compatibility checks. Zero values skip
+// the corresponding check.
+type ValidateOptions struct {
+	// NodeVersion is the Node.js version to check declared "engines.node"
+	// ranges against, e.g. "20.11.0".
+	NodeVersion string
+	// NpmVersion is the npm version to check declared "engines.npm" ranges
+	// against, e.g. "10.2.4".
+	NpmVersion string
+}
+
+// Validate inspects a resolved Graph for problems that MVS resolution
+// succeeds through but a user still needs to know about: unmet peer
+// dependencies, engine mismatches, deprecated packages, and requirement
+// conflicts (a resolved version that fails one of the constraints recorded
+// against it). Issues are sorted by package name for stable output.
+func Validate(graph *Graph, opts ValidateOptions) []Issue {
+	var issues []Issue
+
+	for _, node := range graph.Nodes {
+		issues = append(issues, validatePeerDependencies(graph, node)...)
+		issues = append(issues, validateEngines(node, opts)...)
+		issues = append(issues, validateDeprecated(node)...)
+		issues = append(issues, validateConflicts(node)...)
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Package != issues[j].Package {
+			return issues[i].Package < issues[j].Package
+		}
+		return issues[i].Message < issues[j].Message
+	})
+	return issues
+}
+
+// validatePeerDependencies reports peer dependencies that are missing from
+// the graph entirely, or present at a version that doesn't satisfy the
+// declared constraint. Peers marked optional in peerDependenciesMeta only
+// warn; everything else that's missing is an error.
+func validatePeerDependencies(graph *Graph, node *Node) []Issue {
+	var issues []Issue
+	for peer, constraint := range node.PeerDependencies {
+		optional := node.PeerDependenciesMeta[peer].Optional
+
+		peerNode, ok := graph.Nodes[peer]
+		if !ok {
+			severity := SeverityError
+			if optional {
+				severity = SeverityWarning
+			}
+			issues = append(issues, Issue{
+				Severity: severity,
+				Package:  node.Name,
+				Message:  fmt.Sprintf("%s@%s requires peer %s@%s, which is not installed", node.Name, node.Version, peer, constraint),
+			})
+			continue
+		}
+
+		c, err := semver.NewConstraint(constraint)
+		if err != nil {
+			continue
+		}
+		v, err := semver.NewVersion(peerNode.Version)
+		if err != nil {
+			continue
+		}
+		if !c.Check(v) {
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Package:  node.Name,
+				Message:  fmt.Sprintf("%s@%s requires peer %s@%s, but %s@%s is installed", node.Name, node.Version, peer, constraint, peer, peerNode.Version),
+			})
+		}
+	}
+	return issues
+}
+
+// validateEngines reports declared engines.node/engines.npm ranges the
+// configured toolchain doesn't satisfy.
+func validateEngines(node *Node, opts ValidateOptions) []Issue {
+	var issues []Issue
+	issues = append(issues, checkEngine(node, "node", opts.NodeVersion)...)
+	issues = append(issues, checkEngine(node, "npm", opts.NpmVersion)...)
+	return issues
+}
+
+func checkEngine(node *Node, engine, toolchainVersion string) []Issue {
+	constraint, declared := node.Engines[engine]
+	if !declared || toolchainVersion == "" {
+		return nil
+	}
+
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil
+	}
+	v, err := semver.NewVersion(toolchainVersion)
+	if err != nil {
+		return nil
+	}
+	if c.Check(v) {
+		return nil
+	}
+
+	return []Issue{{
+		Severity: SeverityWarning,
+		Package:  node.Name,
+		Message:  fmt.Sprintf("%s@%s requires %s %s, found %s", node.Name, node.Version, engine, constraint, toolchainVersion),
+	}}
+}
+
+// validateDeprecated reports packages the registry has flagged deprecated.
+func validateDeprecated(node *Node) []Issue {
+	if node.Deprecated == "" {
+		return nil
+	}
+	return []Issue{{
+		Severity: SeverityWarning,
+		Package:  node.Name,
+		Message:  fmt.Sprintf("%s@%s is deprecated: %s", node.Name, node.Version, node.Deprecated),
+	}}
+}
+
+// validateConflicts reports packages whose resolved version fails to
+// satisfy one of its own recorded requirements. Resolve's minimumSatisfying
+// step already rejects these during resolution, so a successfully resolved
+// Graph should never trip this check; it exists as a defensive, reportable
+// counterpart to the last-writer-wins merge this package replaced, which
+// used to paper over exactly this case.
+func validateConflicts(node *Node) []Issue {
+	v, err := semver.NewVersion(node.Version)
+	if err != nil {
+		return nil
+	}
+
+	var issues []Issue
+	for _, req := range node.Requirements {
+		c, err := semver.NewConstraint(req.Constraint)
+		if err != nil {
+			continue
+		}
+		if !c.Check(v) {
+			issues = append(issues, Issue{
+				Severity: SeverityError,
+				Package:  node.Name,
+				Message:  fmt.Sprintf("%s@%s does not satisfy %s required by %s", node.Name, node.Version, req.Constraint, describeParent(req)),
+			})
+		}
+	}
+	return issues
+}
+
+// WriteText renders issues as a human-readable list, one per line.
+func WriteText(w io.Writer, issues []Issue) {
+	for _, issue := range issues {
+		fmt.Fprintf(w, "[%s] %s: %s\n", issue.Severity, issue.Package, issue.Message)
+	}
+}
+
+// WriteJSON renders issues as machine-readable JSON, suitable for CI to fail
+// the build on any SeverityError issue.
+func WriteJSON(w io.Writer, issues []Issue) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(issues)
+}
+
+// HasSeverity reports whether issues contains at least one Issue at or above
+// min. SeverityError is considered more severe than SeverityWarning.
+func HasSeverity(issues []Issue, min Severity) bool {
+	for _, issue := range issues {
+		if severityRank(issue.Severity) >= severityRank(min) {
+			return true
+		}
+	}
+	return false
+}
+
+func severityRank(s Severity) int {
+	if s == SeverityError {
+		return 2
+	}
+	return 1
+}
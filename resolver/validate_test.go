@@ -0,0 +1,176 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zen37/npm_packages/registry"
+)
+
+func TestValidateReportsUnsatisfiedPeerDependency(t *testing.T) {
+	packuments := map[string]registry.Packument{
+		"app": {
+			Name: "app",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"plugin": "^1.0.0"}},
+			},
+		},
+		"plugin": {
+			Name: "plugin",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {PeerDependencies: map[string]string{"host": "^2.0.0"}},
+			},
+		},
+	}
+
+	graph, err := resolveFixture(t, packuments)
+	if err != nil {
+		t.Fatalf("resolveFixture: %v", err)
+	}
+
+	issues := Validate(graph, ValidateOptions{})
+	if !hasIssue(issues, SeverityError, "plugin") {
+		t.Errorf("issues = %+v, want an error for plugin's missing peer dependency", issues)
+	}
+}
+
+func TestValidateReportsDeprecatedPackage(t *testing.T) {
+	packuments := map[string]registry.Packument{
+		"app": {
+			Name: "app",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"old": "^1.0.0"}},
+			},
+		},
+		"old": {
+			Name: "old",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Deprecated: "use new-package instead"},
+			},
+		},
+	}
+
+	graph, err := resolveFixture(t, packuments)
+	if err != nil {
+		t.Fatalf("resolveFixture: %v", err)
+	}
+
+	issues := Validate(graph, ValidateOptions{})
+	if !hasIssue(issues, SeverityWarning, "old") {
+		t.Errorf("issues = %+v, want a warning for the deprecated package", issues)
+	}
+}
+
+func TestValidateReportsEngineMismatch(t *testing.T) {
+	packuments := map[string]registry.Packument{
+		"app": {
+			Name: "app",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"modern": "^1.0.0"}},
+			},
+		},
+		"modern": {
+			Name: "modern",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Engines: map[string]string{"node": ">=20.0.0"}},
+			},
+		},
+	}
+
+	graph, err := resolveFixture(t, packuments)
+	if err != nil {
+		t.Fatalf("resolveFixture: %v", err)
+	}
+
+	issues := Validate(graph, ValidateOptions{NodeVersion: "18.0.0"})
+	if !hasIssue(issues, SeverityWarning, "modern") {
+		t.Errorf("issues = %+v, want a warning for the engine mismatch", issues)
+	}
+}
+
+// TestValidateDoesNotReportStaleConflict covers the same parent-version-bump
+// scenario as TestResolveDropsStaleRequirementsOnParentVersionBump: p's
+// dependency on x is raised from ^1.0.0 to ^2.0.0 when q forces p itself from
+// 1.0.0 to 2.0.0. Validate must not report x@2.0.0 as conflicting with the
+// stale ^1.0.0 constraint p@1.0.0 no longer has any claim to.
+func TestValidateDoesNotReportStaleConflict(t *testing.T) {
+	packuments := map[string]registry.Packument{
+		"app": {
+			Name: "app",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"p": ">=1.0.0", "q": "^1.0.0", "x": ">=1.0.0"}},
+			},
+		},
+		"p": {
+			Name: "p",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"x": "^1.0.0"}},
+				"2.0.0": {Dependencies: map[string]string{"x": "^2.0.0"}},
+			},
+		},
+		"q": {
+			Name: "q",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {Dependencies: map[string]string{"p": "^2.0.0"}},
+			},
+		},
+		"x": {
+			Name: "x",
+			Versions: map[string]registry.VersionInfo{
+				"1.0.0": {},
+				"2.0.0": {},
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path[1:]
+		p, ok := packuments[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(p)
+	}))
+	defer server.Close()
+
+	client := registry.NewClient(registry.WithBaseURL(server.URL))
+	graph, err := Resolve(context.Background(), Root{Name: "app", Version: "1.0.0"}, Options{Client: client, Concurrency: 1})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	issues := Validate(graph, ValidateOptions{})
+	if hasIssue(issues, SeverityError, "x") {
+		t.Errorf("issues = %+v, x@2.0.0 should not conflict with p's stale ^1.0.0 constraint", issues)
+	}
+}
+
+func resolveFixture(t *testing.T, packuments map[string]registry.Packument) (*Graph, error) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path[1:]
+		p, ok := packuments[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(p)
+	}))
+	t.Cleanup(server.Close)
+
+	client := registry.NewClient(registry.WithBaseURL(server.URL))
+	return Resolve(context.Background(), Root{Name: "app", Version: "1.0.0"}, Options{Client: client})
+}
+
+func hasIssue(issues []Issue, severity Severity, pkg string) bool {
+	for _, issue := range issues {
+		if issue.Severity == severity && issue.Package == pkg {
+			return true
+		}
+	}
+	return false
+}